@@ -0,0 +1,142 @@
+// Package analyzer defines the debugleft analysis, which flags calls to
+// d.D (and bare D in the d package itself) that were left in source code.
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/soider/d"
+)
+
+// dImportPath is github.com/soider/d's own import path, used to scope the
+// bare-D() check to source that actually belongs to that package.
+const dImportPath = "github.com/soider/d"
+
+// Analyzer reports leftover calls to d.D so they can be wired into go vet,
+// golangci-lint, or a pre-commit hook.
+var Analyzer = &analysis.Analyzer{
+	Name: "debugleft",
+	Doc:  "report calls to d.D left in source code\n\nThe debugleft analyzer flags every call to d.D (or bare D inside the\nd package itself), since these are debug prints that should not ship.",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inPackageD := pass.Pkg.Path() == dImportPath
+
+	for _, file := range pass.Files {
+		cmap := ast.NewCommentMap(pass.Fset, file, file.Comments)
+		pkgName := d.LocalName(file)
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			list, ok := stmtList(n)
+			if !ok {
+				return true
+			}
+
+			for i, s := range list {
+				stmt, is := s.(*ast.ExprStmt)
+				if !is {
+					continue
+				}
+				call, is := stmt.X.(*ast.CallExpr)
+				if !is || !isDebugCall(call, pkgName, inPackageD) {
+					continue
+				}
+
+				pass.Report(analysis.Diagnostic{
+					Pos:     stmt.Pos(),
+					End:     stmt.End(),
+					Message: "leftover call to " + callName(call),
+					SuggestedFixes: []analysis.SuggestedFix{{
+						Message: "remove call to " + callName(call),
+						TextEdits: []analysis.TextEdit{{
+							Pos: stmt.Pos(),
+							End: removalEnd(pass.Fset, cmap, list, i),
+						}},
+					}},
+				})
+			}
+			return true
+		})
+	}
+
+	return nil, nil
+}
+
+// isDebugCall reports whether call is a leftover d.D() call worth flagging.
+// The pkgName.D() selector form (see d.LocalName) is recognized anywhere,
+// since it unambiguously names github.com/soider/d regardless of which
+// package the call appears in. The bare D() form is only recognized inside
+// github.com/soider/d's own source, since d.IsDFunction only checks the
+// identifier's name and would otherwise flag any unrelated top-level D
+// function a user package happens to declare.
+func isDebugCall(call *ast.CallExpr, pkgName string, inPackageD bool) bool {
+	if inPackageD && d.IsDFunction(call) {
+		return true
+	}
+	return d.IsDPackage(call, pkgName)
+}
+
+// stmtList returns the statement slice n directly contains, if any. Go
+// represents switch and select case bodies as plain []ast.Stmt rather than
+// wrapping them in a BlockStmt, so both need to be checked alongside
+// ordinary blocks for run to see every d.D() call, including ones sitting
+// directly in a case.
+func stmtList(n ast.Node) ([]ast.Stmt, bool) {
+	switch s := n.(type) {
+	case *ast.BlockStmt:
+		return s.List, true
+	case *ast.CaseClause:
+		return s.Body, true
+	case *ast.CommClause:
+		return s.Body, true
+	default:
+		return nil, false
+	}
+}
+
+// removalEnd returns how far the suggested fix should delete to remove
+// list[i] cleanly. If list[i] shares its last line with the next statement
+// (e.g. "d.D(a); d.D(b)"), only list[i] itself is removed, since extending
+// further would eat into a sibling statement's live code. Otherwise list[i]
+// is the last thing on its line, so the edit also consumes any trailing
+// comment attached to it and the line's newline, clearing the line entirely
+// instead of leaving a comment or blank line behind.
+func removalEnd(fset *token.FileSet, cmap ast.CommentMap, list []ast.Stmt, i int) token.Pos {
+	stmt := list[i]
+	end := stmt.End()
+
+	if i+1 < len(list) && fset.Position(list[i+1].Pos()).Line == fset.Position(end).Line {
+		return end
+	}
+
+	for _, c := range cmap[stmt] {
+		if c.End() > end {
+			end = c.End()
+		}
+	}
+	return lineEnd(fset, end)
+}
+
+// lineEnd returns the position just past the end of pos's line, including its
+// trailing newline, or pos itself if pos is on the file's last line.
+func lineEnd(fset *token.FileSet, pos token.Pos) token.Pos {
+	file := fset.File(pos)
+	line := file.Line(pos)
+	if line >= file.LineCount() {
+		return pos
+	}
+	return file.LineStart(line + 1)
+}
+
+// callName returns "D" or "d.D" depending on which form of the call n is, for
+// use in diagnostic messages.
+func callName(n *ast.CallExpr) string {
+	if d.IsDFunction(n) {
+		return "D"
+	}
+	return "d.D"
+}
@@ -0,0 +1,44 @@
+package a
+
+import "d"
+
+// D shadows the package-level D that the real d package exports. Since
+// package a isn't github.com/soider/d, calls to this D must not be flagged --
+// only debugleft's fixture under github.com/soider/d itself exercises the
+// bare-call form.
+func D(args ...interface{}) {}
+
+func f() {
+	x := 1
+	d.D(x) // want "leftover call to d.D"
+	D(x)
+	println(x)
+}
+
+// g exercises two d.D() calls sharing a line, which the suggested fix must
+// not let bleed into each other's live code.
+func g() {
+	p, q := 1, 2
+	d.D(p); d.D(q) // want "leftover call to d.D" "leftover call to d.D"
+	println(p, q)
+}
+
+// h exercises a d.D() call sitting directly in a switch case body, which
+// isn't wrapped in a BlockStmt.
+func h(x int) {
+	switch x {
+	case 1:
+		d.D(x) // want "leftover call to d.D"
+	default:
+		println(x)
+	}
+}
+
+// sel exercises a d.D() call sitting directly in a select case body, which
+// also isn't wrapped in a BlockStmt.
+func sel(ch chan int) {
+	select {
+	case v := <-ch:
+		d.D(v) // want "leftover call to d.D"
+	}
+}
@@ -0,0 +1,5 @@
+// Package d is a stub of github.com/soider/d for the debugleft testdata.
+package d
+
+// D is a stand-in for the real debug print function.
+func D(args ...interface{}) []interface{} { return args }
@@ -0,0 +1,13 @@
+// Package d stands in for github.com/soider/d itself, so debugleft's
+// bare-D() case -- which only fires inside this package's own path -- has
+// something to run against.
+package d
+
+// D stands in for the real package's exported debug print function.
+func D(args ...interface{}) {}
+
+func internal() {
+	x := 1
+	D(x) // want "leftover call to D"
+	println(x)
+}
@@ -0,0 +1,13 @@
+// Command debugleft runs the debugleft analyzer standalone, reporting any
+// calls to d.D left in the given packages.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/soider/d/analyzer"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
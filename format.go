@@ -0,0 +1,191 @@
+package d
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Formatter renders a value as a string for d.D() output. Format returns
+// ok == false to decline, leaving the value to pretty.Sprint; only one
+// Formatter (the one lookupFormatter picks) is consulted per value.
+type Formatter interface {
+	Format(v interface{}) (string, bool)
+}
+
+// FormatterFunc adapts a plain function to a Formatter.
+type FormatterFunc func(v interface{}) (string, bool)
+
+// Format calls f(v).
+func (f FormatterFunc) Format(v interface{}) (string, bool) {
+	return f(v)
+}
+
+// formatters maps a type to the Formatter responsible for rendering values of
+// it. Concrete types (time.Time, []byte, ...) are matched exactly; interface
+// types (error, proto.Message) are matched by Implements in lookupFormatter
+// once an exact match fails. d.D() is meant to be sprinkled through
+// concurrent code, and RegisterFormatter is a public API users are expected
+// to call at any time, so formattersMu guards every read and write.
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[reflect.Type]Formatter{}
+)
+
+// RegisterFormatter makes f responsible for rendering values of typ in d.D()
+// output, ahead of the pretty.Sprint fallback. typ may be a concrete type
+// (e.g. reflect.TypeOf(time.Time{})), matched exactly, or an interface type
+// (e.g. reflect.TypeOf((*error)(nil)).Elem()), matched against any value that
+// implements it. Registering the same typ again replaces the formatter.
+// RegisterFormatter is safe to call concurrently with d.D().
+func RegisterFormatter(typ reflect.Type, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[typ] = f
+}
+
+func init() {
+	RegisterFormatter(reflect.TypeOf(time.Time{}), FormatterFunc(formatTime))
+	RegisterFormatter(reflect.TypeOf(time.Duration(0)), FormatterFunc(formatDuration))
+	RegisterFormatter(reflect.TypeOf([]byte(nil)), FormatterFunc(formatBytes))
+	RegisterFormatter(reflect.TypeOf(json.RawMessage(nil)), FormatterFunc(formatRawJSON))
+	RegisterFormatter(reflect.TypeOf((*error)(nil)).Elem(), FormatterFunc(formatError))
+	RegisterFormatter(reflect.TypeOf((*proto.Message)(nil)).Elem(), FormatterFunc(formatProto))
+}
+
+// lookupFormatter returns the Formatter registered for t, if any. t is
+// matched exactly first; failing that, lookupFormatter checks t against
+// every registered interface type, since those can't be keyed by a single
+// concrete type.
+func lookupFormatter(t reflect.Type) (Formatter, bool) {
+	if t == nil {
+		return nil, false
+	}
+
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+
+	if f, ok := formatters[t]; ok {
+		return f, true
+	}
+	for typ, f := range formatters {
+		if typ.Kind() == reflect.Interface && t.Implements(typ) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// formatTime renders a time.Time as RFC3339 in both its own zone and local
+// time, since the two frequently disagree and both are useful while
+// debugging.
+func formatTime(v interface{}) (string, bool) {
+	t, is := v.(time.Time)
+	if !is {
+		return "", false
+	}
+	return fmt.Sprintf("%s (local %s)", t.Format(time.RFC3339), t.Local().Format(time.RFC3339)), true
+}
+
+// formatDuration renders a time.Duration using its human-readable String
+// form, e.g. "1h2m3s", instead of pretty.Sprint's raw nanosecond count.
+func formatDuration(v interface{}) (string, bool) {
+	dur, is := v.(time.Duration)
+	if !is {
+		return "", false
+	}
+	return dur.String(), true
+}
+
+// formatBytes renders a []byte as a hexdump -C style dump: offset, hex bytes,
+// and an ASCII gutter, 16 bytes per line.
+func formatBytes(v interface{}) (string, bool) {
+	b, is := v.([]byte)
+	if !is {
+		return "", false
+	}
+
+	const width = 16
+	var buf strings.Builder
+	for offset := 0; offset < len(b); offset += width {
+		end := offset + width
+		if end > len(b) {
+			end = len(b)
+		}
+		line := b[offset:end]
+
+		fmt.Fprintf(&buf, "%08x  ", offset)
+		for i := 0; i < width; i++ {
+			switch {
+			case i < len(line):
+				fmt.Fprintf(&buf, "%02x ", line[i])
+			default:
+				buf.WriteString("   ")
+			}
+			if i == width/2-1 {
+				buf.WriteByte(' ')
+			}
+		}
+
+		buf.WriteString(" |")
+		for _, c := range line {
+			if c >= 0x20 && c < 0x7f {
+				buf.WriteByte(c)
+			} else {
+				buf.WriteByte('.')
+			}
+		}
+		buf.WriteString("|\n")
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+// formatRawJSON renders a json.RawMessage as indented JSON, falling back to
+// the raw bytes if the message doesn't parse.
+func formatRawJSON(v interface{}) (string, bool) {
+	raw, is := v.(json.RawMessage)
+	if !is {
+		return "", false
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return string(raw), true
+	}
+	return buf.String(), true
+}
+
+// formatError renders an error together with its unwrap chain, e.g.
+// "timeout -> context deadline exceeded", so wrapped errors don't collapse
+// into a single opaque message.
+func formatError(v interface{}) (string, bool) {
+	err, is := v.(error)
+	if !is {
+		return "", false
+	}
+
+	var chain []string
+	for err != nil {
+		chain = append(chain, err.Error())
+		err = errors.Unwrap(err)
+	}
+	return strings.Join(chain, " -> "), true
+}
+
+// formatProto renders a proto.Message using protobuf text format, which is
+// far more readable than pretty.Sprint's view of the generated struct's
+// internal fields.
+func formatProto(v interface{}) (string, bool) {
+	m, is := v.(proto.Message)
+	if !is {
+		return "", false
+	}
+	return prototext.Format(m), true
+}
@@ -0,0 +1,67 @@
+package d
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatValueBuiltins(t *testing.T) {
+	wrapped := fmt.Errorf("request failed: %w", errors.New("timeout"))
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"duration", 90 * time.Minute, "1h30m0s"},
+		{"bytes", []byte("hi"), "00000000  68 69                                             |hi|"},
+		{"raw json", json.RawMessage(`{"a":1}`), "{\n  \"a\": 1\n}"},
+		{"error chain", wrapped, "request failed: timeout -> timeout"},
+	}
+
+	for _, tt := range tests {
+		if got := formatValue(tt.v); got != tt.want {
+			t.Errorf("formatValue(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type upperFormatter struct{}
+
+func (upperFormatter) Format(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	out := ""
+	for _, r := range s {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		out += string(r)
+	}
+	return out, true
+}
+
+func TestRegisterFormatterOverridesDefault(t *testing.T) {
+	typ := reflect.TypeOf("")
+	prev, hadPrev := lookupFormatter(typ)
+	RegisterFormatter(typ, upperFormatter{})
+	defer func() {
+		formattersMu.Lock()
+		defer formattersMu.Unlock()
+		if hadPrev {
+			formatters[typ] = prev
+		} else {
+			delete(formatters, typ)
+		}
+	}()
+
+	if got, want := formatValue("hi"), "HI"; got != want {
+		t.Errorf("formatValue(%q) = %q, want %q", "hi", got, want)
+	}
+}
@@ -8,8 +8,12 @@ import (
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/kr/pretty"
@@ -17,6 +21,10 @@ import (
 
 var colorizeEnabled = true
 
+// dImportPath is the import path of this package, used to recognize aliased
+// and dot imports of it when scanning caller source.
+const dImportPath = "github.com/soider/d"
+
 // argName returns the source text of the given argument if it's a variable or
 // an expression. If the argument is something else, like a literal, argName
 // returns an empty string.
@@ -41,19 +49,27 @@ func argName(arg ast.Expr) string {
 	return name
 }
 
-// argNames finds the d.D() call at the given filename/line number and
-// returns its arguments as a slice of strings. If the argument is a literal,
-// argNames will return an empty string at the index position of that argument.
-// For example, d.D(ip, port, 5432) would return []string{"ip", "port", ""}.
+// argNames finds the d.D() calls at the given filename/line number and
+// returns each one's arguments as a slice of strings, in left-to-right source
+// order. If an argument is a literal, argNames will return an empty string at
+// the index position of that argument. For example, a line holding only
+// d.D(ip, port, 5432) would return [][]string{{"ip", "port", ""}}.
+//
+// Usually there's only one call on the line and the result has a single
+// element. When several d.D() calls share a line -- e.g. d.D(a); d.D(b), or
+// two calls chained in the same expression -- argNames returns one slice per
+// call so the caller can disambiguate; see argNamesForCall.
 // argNames returns an error if the source text cannot be parsed.
-func argNames(filename string, line int) ([]string, error) {
+func argNames(filename string, line int) ([][]string, error) {
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, filename, nil, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse %q: %v", filename, err)
 	}
 
-	var names []string
+	pkgName := LocalName(f)
+
+	var calls [][]string
 	ast.Inspect(f, func(n ast.Node) bool {
 		call, is := n.(*ast.CallExpr)
 		if !is {
@@ -66,19 +82,68 @@ func argNames(filename string, line int) ([]string, error) {
 			return true
 		}
 
-		if !isDCall(call) {
+		if !IsDCall(call, pkgName) {
 			// The node is a function call on correct line, but it's not a Q()
 			// function.
 			return true
 		}
 
+		var names []string
 		for _, arg := range call.Args {
 			names = append(names, argName(arg))
 		}
+		calls = append(calls, names)
 		return true
 	})
 
-	return names, nil
+	return calls, nil
+}
+
+// argNamesForCall finds the d.D() calls at the given filename/line number and
+// returns the argument names belonging to the callIndex'th one, wrapping
+// modulo the number of calls found. Go's runtime doesn't expose the column a
+// caller made its call from (runtime.Caller and runtime.CallersFrames report
+// only file and line), so there's no way to directly pick the right
+// ast.CallExpr when several share a line. Instead, callers track how many
+// times each (file, line) pair has actually been invoked -- see
+// nextCallIndex -- and since d.D() calls on one line always run in the same
+// left-to-right order argNames already returns them in, indexing by call
+// count recovers the right argument names.
+func argNamesForCall(filename string, line, callIndex int) ([]string, error) {
+	calls, err := argNames(filename, line)
+	if err != nil {
+		return nil, err
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	return calls[callIndex%len(calls)], nil
+}
+
+// LocalName returns the identifier that f binds the github.com/soider/d
+// import to: "d" for a plain import, the chosen name for an aliased import,
+// or "." for a dot import. If f doesn't import the package at all, LocalName
+// falls back to "d" so callers keep working on files that never imported it
+// under an unusual name (the common case: files living inside the d package
+// itself, which call D() directly). It's exported so other d.D()-aware tools,
+// like the debugleft analyzer, can resolve the same aliasing this package's
+// own argNames does instead of re-implementing it.
+func LocalName(f *ast.File) string {
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || path != dImportPath {
+			continue
+		}
+		switch {
+		case imp.Name == nil:
+			return "d"
+		case imp.Name.Name == "_":
+			continue // blank import can't be calling D()
+		default:
+			return imp.Name.Name // aliased name, or "." for a dot import
+		}
+	}
+	return "d"
 }
 
 // argWidth returns the number of characters that will be seen when the given
@@ -124,23 +189,67 @@ func exprToString(arg ast.Expr) string {
 func formatArgs(args ...interface{}) []string {
 	formatted := make([]string, 0, len(args))
 	for _, a := range args {
-		s := colorize(pretty.Sprint(a), cyan)
-		formatted = append(formatted, s)
+		formatted = append(formatted, colorize(formatValue(a), cyan))
 	}
 	return formatted
 }
 
-// getCallerInfo returns the name, file, and line number of the function calling
-// d.D().
-func getCallerInfo() (funcName, file string, line int, err error) {
+// formatValue renders a single value, consulting the Formatter registry
+// before falling back to pretty.Sprint.
+func formatValue(v interface{}) string {
+	if v != nil {
+		if f, ok := lookupFormatter(reflect.TypeOf(v)); ok {
+			if s, ok := f.Format(v); ok {
+				return s
+			}
+		}
+	}
+	return pretty.Sprint(v)
+}
+
+// getCallerInfo returns the name, file, and line number of the function
+// calling d.D(), along with a callIndex identifying which d.D() call on that
+// line this particular invocation is -- see argNamesForCall.
+func getCallerInfo() (funcName, file string, line, callIndex int, err error) {
 	const callDepth = 2 // user code calls d.D() which calls std.log().
 	pc, file, line, ok := runtime.Caller(callDepth)
 	if !ok {
-		return "", "", 0, errors.New("failed to get info about the function calling d.D")
+		return "", "", 0, 0, errors.New("failed to get info about the function calling d.D")
 	}
 
 	funcName = runtime.FuncForPC(pc).Name()
-	return funcName, file, line, nil
+	callIndex = nextCallIndex(file, line)
+	return funcName, file, line, callIndex, nil
+}
+
+// callSite identifies a source position that called d.D().
+type callSite struct {
+	file string
+	line int
+}
+
+// callCounts tracks how many times D() has been called from each callSite,
+// so that multiple d.D() calls sharing a line can be told apart by call
+// order since the column isn't available (see argNamesForCall).
+var callCounts sync.Map // map[callSite]*uint64
+
+// nextCallIndex returns how many times D() has previously been called from
+// file:line, then records this call too. The first call at a given site
+// returns 0, the second returns 1, and so on.
+//
+// This assumes a given call site's invocations reach here in source order,
+// which holds for a single goroutine looping over or falling through several
+// d.D() calls on one line. If multiple goroutines call the same shared-line
+// call site concurrently, their increments can interleave, and
+// argNamesForCall may attribute one goroutine's call to another's argument
+// names. There's no public API to resolve a caller's column to tell the
+// calls apart more precisely (see argNamesForCall), so this is accepted as a
+// known limitation rather than one this package can fix.
+func nextCallIndex(file string, line int) int {
+	site := callSite{file, line}
+	v, _ := callCounts.LoadOrStore(site, new(uint64))
+	counter := v.(*uint64)
+	return int(atomic.AddUint64(counter, 1) - 1)
 }
 
 // prependArgName turns argument names and values into name=value strings, e.g.
@@ -159,13 +268,20 @@ func prependArgName(names, values []string) []string {
 	return prepended
 }
 
-// isDCall returns true if the given function call expression is D() or d.D().
-func isDCall(n *ast.CallExpr) bool {
-	return isDFunction(n) || isDPackage(n)
+// IsDCall returns true if the given function call expression is D() or
+// pkgName.D(), where pkgName is the local name the caller's file bound
+// github.com/soider/d to (see LocalName). For a dot import, pkgName is "."
+// and the selector form can't occur, so IsDFunction alone covers it.
+//
+// IsDCall, IsDFunction, and IsDPackage are exported so other d.D()-aware
+// tools, like the debugleft analyzer, share this package's own notion of
+// what counts as a d.D() call instead of drifting from it over time.
+func IsDCall(n *ast.CallExpr, pkgName string) bool {
+	return IsDFunction(n) || IsDPackage(n, pkgName)
 }
 
-// isDFunction returns true if the given function call expression is D().
-func isDFunction(n *ast.CallExpr) bool {
+// IsDFunction returns true if the given function call expression is D().
+func IsDFunction(n *ast.CallExpr) bool {
 	ident, is := n.Fun.(*ast.Ident)
 	if !is {
 		return false
@@ -173,10 +289,16 @@ func isDFunction(n *ast.CallExpr) bool {
 	return ident.Name == "D"
 }
 
-// isDPackage returns true if the given function call expression is in the d
-// package. Since D() is the only exported function from the d package, this is
-// sufficient for determining that we've found D() in the source text.
-func isDPackage(n *ast.CallExpr) bool {
+// IsDPackage returns true if the given function call expression is
+// pkgName.D(). Both the package name and the selected name are checked, so
+// calls to the package's other exported functions (e.g.
+// pkgName.RegisterFormatter) don't match. For a dot import pkgName is "."
+// and IsDPackage always returns false,
+// since a dot-imported D() is called bare and matched by IsDFunction instead.
+func IsDPackage(n *ast.CallExpr, pkgName string) bool {
+	if pkgName == "." {
+		return false
+	}
 	sel, is := n.Fun.(*ast.SelectorExpr) // SelectorExpr example: a.B()
 	if !is {
 		return false
@@ -185,5 +307,5 @@ func isDPackage(n *ast.CallExpr) bool {
 	if !is {
 		return false
 	}
-	return ident.Name == "d"
+	return ident.Name == pkgName && sel.Sel.Name == "D"
 }
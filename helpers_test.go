@@ -0,0 +1,75 @@
+package d
+
+import "testing"
+
+func TestDLocalName(t *testing.T) {
+	tests := []struct {
+		file string
+		line int
+		want [][]string
+	}{
+		{"testdata/aliased_import.go", 7, [][]string{{"port"}}},
+		{"testdata/dot_import.go", 7, [][]string{{"port"}}},
+	}
+
+	for _, tt := range tests {
+		got, err := argNames(tt.file, tt.line)
+		if err != nil {
+			t.Fatalf("argNames(%q, %d): %v", tt.file, tt.line, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("argNames(%q, %d) = %v, want %v", tt.file, tt.line, got, tt.want)
+		}
+		for i := range got {
+			if len(got[i]) != len(tt.want[i]) {
+				t.Fatalf("argNames(%q, %d)[%d] = %v, want %v", tt.file, tt.line, i, got[i], tt.want[i])
+			}
+			for j := range got[i] {
+				if got[i][j] != tt.want[i][j] {
+					t.Errorf("argNames(%q, %d)[%d][%d] = %q, want %q", tt.file, tt.line, i, j, got[i][j], tt.want[i][j])
+				}
+			}
+		}
+	}
+}
+
+func TestArgNamesForCallSharedLine(t *testing.T) {
+	const file = "testdata/shared_line.go"
+	const line = 7
+
+	first, err := argNamesForCall(file, line, 0)
+	if err != nil {
+		t.Fatalf("argNamesForCall(%q, %d, 0): %v", file, line, err)
+	}
+	if want := []string{"a"}; len(first) != 1 || first[0] != want[0] {
+		t.Errorf("argNamesForCall(%q, %d, 0) = %v, want %v", file, line, first, want)
+	}
+
+	second, err := argNamesForCall(file, line, 1)
+	if err != nil {
+		t.Fatalf("argNamesForCall(%q, %d, 1): %v", file, line, err)
+	}
+	if want := []string{"b"}; len(second) != 1 || second[0] != want[0] {
+		t.Errorf("argNamesForCall(%q, %d, 1) = %v, want %v", file, line, second, want)
+	}
+
+	// callIndex wraps around the number of calls found on the line.
+	wrapped, err := argNamesForCall(file, line, 2)
+	if err != nil {
+		t.Fatalf("argNamesForCall(%q, %d, 2): %v", file, line, err)
+	}
+	if len(wrapped) != 1 || wrapped[0] != first[0] {
+		t.Errorf("argNamesForCall(%q, %d, 2) = %v, want %v", file, line, wrapped, first)
+	}
+}
+
+func TestNextCallIndex(t *testing.T) {
+	const file = "shared_line_counter_test.go"
+	const line = 42
+
+	for want := 0; want < 3; want++ {
+		if got := nextCallIndex(file, line); got != want {
+			t.Errorf("nextCallIndex(%q, %d) = %d, want %d", file, line, got, want)
+		}
+	}
+}
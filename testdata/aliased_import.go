@@ -0,0 +1,8 @@
+package testdata
+
+import xd "github.com/soider/d"
+
+func aliasedCall() {
+	port := 443
+	xd.D(port)
+}
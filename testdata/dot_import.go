@@ -0,0 +1,8 @@
+package testdata
+
+import . "github.com/soider/d"
+
+func dotCall() {
+	port := 443
+	D(port)
+}
@@ -0,0 +1,8 @@
+package testdata
+
+import "github.com/soider/d"
+
+func sharedLine() {
+	a, b := 1, 2
+	d.D(a); d.D(b)
+}